@@ -0,0 +1,214 @@
+// +build go1.7
+
+package ginhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+// Transport wraps a RoundTripper. For every request it starts a client span
+// with Tracer, injects that span into the headers, and sets HTTP related
+// tags on the span. It uses the span found in the
+// request's context as the parent, so it should be used together with
+// Middleware (or any other code that injects a span into the request
+// context) to propagate traces outbound. Options are forwarded to
+// TraceRequest for every request.
+type Transport struct {
+	// The actual RoundTripper to use for the request. A nil
+	// RoundTripper defaults to http.DefaultTransport.
+	RoundTripper http.RoundTripper
+
+	// Tracer is used to start the client-side span. A nil Tracer defaults
+	// to opentracing.GlobalTracer().
+	Tracer opentracing.Tracer
+
+	// Options are passed through to TraceRequest for every request,
+	// e.g. ClientOperationNameFunc, ClientURLTagFunc, ClientComponentName
+	// or ClientTrace.
+	Options []ClientOption
+}
+
+type clientOptions struct {
+	opNameFunc    func(r *http.Request) string
+	componentName string
+	urlTagFunc    func(u *url.URL) string
+	clientTrace   bool
+}
+
+// ClientOption controls the behavior of TraceRequest.
+type ClientOption func(*clientOptions)
+
+// ClientOperationNameFunc returns a ClientOption that uses given function f
+// to generate the operation name for each client-side span.
+func ClientOperationNameFunc(f func(r *http.Request) string) ClientOption {
+	if f == nil {
+		panic("nil ClientOperationNameFunc")
+	}
+
+	return func(options *clientOptions) {
+		options.opNameFunc = f
+	}
+}
+
+// ClientComponentName returns a ClientOption that sets the component name
+// for the client-side span.
+func ClientComponentName(componentName string) ClientOption {
+	if componentName == "" {
+		panic("empty componentName")
+	}
+
+	return func(options *clientOptions) {
+		options.componentName = componentName
+	}
+}
+
+// ClientURLTagFunc returns a ClientOption that uses given function f to set
+// the span's http.url tag. Can be used to change the default http.url tag,
+// eg to redact sensitive information.
+func ClientURLTagFunc(f func(u *url.URL) string) ClientOption {
+	if f == nil {
+		panic("nil ClientURLTagFunc")
+	}
+
+	return func(options *clientOptions) {
+		options.urlTagFunc = f
+	}
+}
+
+// ClientTrace returns a ClientOption that wires a net/http/httptrace.ClientTrace
+// into the outgoing request, recording connection and TLS handshake timing
+// as span logs.
+func ClientTrace() ClientOption {
+	return func(options *clientOptions) {
+		options.clientTrace = true
+	}
+}
+
+// Tracer holds the client-side span for a single traced request. The
+// httptrace hooks enabled by ClientTrace log onto this span; they start no
+// child spans.
+type Tracer struct {
+	sp opentracing.Span
+}
+
+// TraceRequest starts a SpanKindRPCClient span for req with tr, as a child
+// of the span found in req's context (if any), injects the resulting span
+// context into the outgoing request headers, and returns a request carrying
+// the new span plus the Tracer tracking it. The caller is responsible for
+// calling Tracer.Finish once the response has been obtained.
+func TraceRequest(tr opentracing.Tracer, req *http.Request, opts ...ClientOption) (*http.Request, *Tracer) {
+	options := clientOptions{
+		opNameFunc: func(r *http.Request) string {
+			return "HTTP " + r.Method
+		},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx := req.Context()
+	var parent opentracing.SpanContext
+	if parentSpan := opentracing.SpanFromContext(ctx); parentSpan != nil {
+		parent = parentSpan.Context()
+	}
+
+	sp := tr.StartSpan(options.opNameFunc(req), ext.SpanKindRPCClient, opentracing.ChildOf(parent))
+	ext.HTTPMethod.Set(sp, req.Method)
+	if options.urlTagFunc != nil {
+		ext.HTTPUrl.Set(sp, options.urlTagFunc(req.URL))
+	} else {
+		ext.HTTPUrl.Set(sp, req.URL.String())
+	}
+	componentName := options.componentName
+	if componentName == "" {
+		componentName = defaultComponentName
+	}
+	ext.Component.Set(sp, componentName)
+
+	ctx = opentracing.ContextWithSpan(ctx, sp)
+	if options.clientTrace {
+		ctx = httptrace.WithClientTrace(ctx, clientTrace(sp))
+	}
+	req = req.WithContext(ctx)
+
+	_ = tr.Inject(sp.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+
+	return req, &Tracer{sp: sp}
+}
+
+// Finish sets the http.status_code and error tags on the span and finishes
+// it. err is the error (if any) returned by the RoundTripper.
+func (t *Tracer) Finish(resp *http.Response, err error) {
+	if err != nil {
+		ext.Error.Set(t.sp, true)
+		t.sp.LogFields(otlog.Event("error"), otlog.Error(err))
+	} else if resp != nil {
+		ext.HTTPStatusCode.Set(t.sp, uint16(resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			ext.Error.Set(t.sp, true)
+		}
+	}
+	t.sp.Finish()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.RoundTripper
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	tr := t.Tracer
+	if tr == nil {
+		tr = opentracing.GlobalTracer()
+	}
+
+	req, tracer := TraceRequest(tr, req, t.Options...)
+	resp, err := rt.RoundTrip(req)
+	tracer.Finish(resp, err)
+	return resp, err
+}
+
+// clientTrace returns the httptrace.ClientTrace hooks that record timing of
+// the request's network and TLS handshake phases as log fields on sp.
+func clientTrace(sp opentracing.Span) *httptrace.ClientTrace {
+	logEvent := func(event string, fields ...otlog.Field) {
+		sp.LogFields(append([]otlog.Field{otlog.String("event", event)}, fields...)...)
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			logEvent("GetConn", otlog.String("host_port", hostPort))
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			logEvent("DNSStart", otlog.String("host", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			logEvent("DNSDone", otlog.Error(info.Err))
+		},
+		ConnectStart: func(network, addr string) {
+			logEvent("ConnectStart", otlog.String("network", network), otlog.String("addr", addr))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			logEvent("ConnectDone", otlog.String("network", network), otlog.String("addr", addr), otlog.Error(err))
+		},
+		TLSHandshakeStart: func() {
+			logEvent("TLSHandshakeStart")
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			logEvent("TLSHandshakeDone", otlog.Error(err))
+		},
+		GotFirstResponseByte: func() {
+			logEvent("GotFirstResponseByte")
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			logEvent("WroteRequest", otlog.Error(info.Err))
+		},
+	}
+}
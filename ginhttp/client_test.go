@@ -0,0 +1,85 @@
+package ginhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeitlinger/go-gin/ginhttp"
+)
+
+func TestTransport(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+
+	var extracted opentracing.SpanContext
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extracted, _ = tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &ginhttp.Transport{}}
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	tags := span.Tags()
+	assert.Equal(t, "GET", tags[string(ext.HTTPMethod)])
+	assert.Equal(t, uint16(http.StatusOK), tags[string(ext.HTTPStatusCode)])
+	assert.Equal(t, ext.SpanKindRPCClientEnum, tags[string(ext.SpanKind)])
+
+	// The headers the server extracted should carry the client span's own
+	// trace/span IDs, proving RoundTrip actually injected them.
+	mockCtx, ok := extracted.(mocktracer.MockSpanContext)
+	assert.True(t, ok)
+	assert.Equal(t, span.SpanContext.TraceID, mockCtx.TraceID)
+	assert.Equal(t, span.SpanContext.SpanID, mockCtx.SpanID)
+}
+
+func TestTransportOptions(t *testing.T) {
+	tracer := mocktracer.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &ginhttp.Transport{
+		Tracer:  tracer,
+		Options: []ginhttp.ClientOption{ginhttp.ClientComponentName("my-client")},
+	}}
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "my-client", spans[0].Tags()[string(ext.Component)])
+}
+
+func TestTraceRequestInjectsHeaders(t *testing.T) {
+	tracer := mocktracer.New()
+	req, err := http.NewRequest("GET", "http://example.com/hello", nil)
+	assert.NoError(t, err)
+
+	req, tr := ginhttp.TraceRequest(tracer, req)
+	extracted, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	assert.NoError(t, err)
+	mockCtx, ok := extracted.(mocktracer.MockSpanContext)
+	assert.True(t, ok)
+
+	tr.Finish(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, spans[0].SpanContext.TraceID, mockCtx.TraceID)
+	assert.Equal(t, spans[0].SpanContext.SpanID, mockCtx.SpanID)
+}
@@ -0,0 +1,45 @@
+// +build go1.7
+
+package ginhttp
+
+import "github.com/gin-gonic/gin"
+
+// ResponseWriter wraps gin.ResponseWriter so Middleware can, in addition to
+// the status and size gin already tracks, optionally capture the response
+// body (up to a cap) for finer-grained error classification in MWErrorFunc.
+type ResponseWriter struct {
+	gin.ResponseWriter
+	bodyCap int
+	body    []byte
+}
+
+// Body returns the response body captured so far, up to the cap configured
+// via MWCaptureResponseBody. It is empty unless that option was used.
+func (w *ResponseWriter) Body() []byte {
+	return w.body
+}
+
+// Write implements http.ResponseWriter.
+func (w *ResponseWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.capture(data[:n])
+	return n, err
+}
+
+// WriteString implements gin.ResponseWriter.
+func (w *ResponseWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.capture([]byte(s[:n]))
+	return n, err
+}
+
+func (w *ResponseWriter) capture(data []byte) {
+	remaining := w.bodyCap - len(w.body)
+	if remaining <= 0 {
+		return
+	}
+	if len(data) > remaining {
+		data = data[:remaining]
+	}
+	w.body = append(w.body, data...)
+}
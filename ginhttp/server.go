@@ -19,16 +19,22 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
 )
 
 const defaultComponentName = "net/http"
 
 type mwOptions struct {
-	opNameFunc    func(r *http.Request) string
-	spanObserver  func(span opentracing.Span, r *http.Request)
-	urlTagFunc    func(u *url.URL) string
-	errorFunc     func(ctx *gin.Context) bool
-	componentName string
+	opNameFunc       func(r *http.Request) string
+	useRouteTemplate bool
+	spanObserver     func(span opentracing.Span, r *http.Request)
+	urlTagFunc       func(u *url.URL) string
+	errorFunc        func(ctx *gin.Context, w *ResponseWriter) bool
+	componentName    string
+	requestHeaders   []string
+	responseHeaders  []string
+	responseSizeTag  bool
+	responseBodyCap  int
 }
 
 // MWOption controls the behavior of the Middleware.
@@ -43,6 +49,19 @@ func OperationNameFunc(f func(r *http.Request) string) MWOption {
 
 	return func(options *mwOptions) {
 		options.opNameFunc = f
+		options.useRouteTemplate = false
+	}
+}
+
+// MWUseRouteTemplate returns a MWOption that names the server-side span
+// (and tags it with http.route) after gin's matched route template, e.g.
+// "/users/:id", instead of the raw request path. This is the default when
+// no OperationNameFunc is given, since it avoids the cardinality explosion
+// of per-ID span names. Unmatched routes (no FullPath, e.g. 404s) fall
+// back to "HTTP <METHOD>".
+func MWUseRouteTemplate() MWOption {
+	return func(options *mwOptions) {
+		options.useRouteTemplate = true
 	}
 }
 
@@ -83,8 +102,11 @@ func MWURLTagFunc(f func(u *url.URL) string) MWOption {
 	}
 }
 
-// MWErrorFunc returns a MWOption that sets the span error tag
-func MWErrorFunc(f func(ctx *gin.Context) bool) MWOption {
+// MWErrorFunc returns a MWOption that sets the span error tag. It is given
+// the wrapping ResponseWriter, so it can classify errors on more than just
+// status, e.g. on captured response body content (see
+// MWCaptureResponseBody) or on ctx.Errors.
+func MWErrorFunc(f func(ctx *gin.Context, w *ResponseWriter) bool) MWOption {
 	if f == nil {
 		panic("nil MWErrorFunc")
 	}
@@ -94,14 +116,49 @@ func MWErrorFunc(f func(ctx *gin.Context) bool) MWOption {
 	}
 }
 
+// MWResponseSizeTag returns a MWOption that adds an
+// "http.response_content_length" span tag with the number of bytes written
+// to the response body.
+func MWResponseSizeTag() MWOption {
+	return func(options *mwOptions) {
+		options.responseSizeTag = true
+	}
+}
+
+// MWCaptureResponseBody returns a MWOption that captures up to capBytes of
+// the response body on the ResponseWriter passed to MWErrorFunc.
+func MWCaptureResponseBody(capBytes int) MWOption {
+	return func(options *mwOptions) {
+		options.responseBodyCap = capBytes
+	}
+}
+
+// MWCaptureRequestHeaders returns a MWOption that adds a
+// "http.request.header.<name>" span tag for each of the given request
+// header names, e.g. "X-Request-Id" -> "http.request.header.x_request_id".
+func MWCaptureRequestHeaders(headers []string) MWOption {
+	return func(options *mwOptions) {
+		options.requestHeaders = headers
+	}
+}
+
+// MWCaptureResponseHeaders returns a MWOption that adds a
+// "http.response.header.<name>" span tag for each of the given response
+// header names, e.g. "X-Request-Id" -> "http.response.header.x_request_id".
+func MWCaptureResponseHeaders(headers []string) MWOption {
+	return func(options *mwOptions) {
+		options.responseHeaders = headers
+	}
+}
+
 // Middleware is a gin native version of the equivalent middleware in:
 //   https://github.com/opentracing-contrib/go-stdlib/
 func Middleware(tr opentracing.Tracer, options ...MWOption) gin.HandlerFunc {
 	opts := mwOptions{
-		opNameFunc:   defaultOperationName,
-		spanObserver: func(span opentracing.Span, r *http.Request) {},
-		errorFunc: func(ctx *gin.Context) bool {
-			return ctx.Writer.Status() >= http.StatusInternalServerError
+		useRouteTemplate: true,
+		spanObserver:     func(span opentracing.Span, r *http.Request) {},
+		errorFunc: func(ctx *gin.Context, w *ResponseWriter) bool {
+			return w.Status() >= http.StatusInternalServerError
 		},
 	}
 	for _, opt := range options {
@@ -109,9 +166,20 @@ func Middleware(tr opentracing.Tracer, options ...MWOption) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
+		rw := &ResponseWriter{ResponseWriter: c.Writer, bodyCap: opts.responseBodyCap}
+		c.Writer = rw
+
 		carrier := opentracing.HTTPHeadersCarrier(c.Request.Header)
 		ctx, _ := tr.Extract(opentracing.HTTPHeaders, carrier)
-		op := opts.opNameFunc(c.Request)
+		// When using the route template, the matched route isn't known
+		// until after c.Next(), so start the span with a placeholder name
+		// and rename it once routing has happened.
+		var op string
+		if opts.useRouteTemplate {
+			op = unmatchedOperationName(c.Request)
+		} else {
+			op = opts.opNameFunc(c.Request)
+		}
 		sp := tr.StartSpan(op, ext.RPCServerOption(ctx))
 		ext.HTTPMethod.Set(sp, c.Request.Method)
 		if opts.urlTagFunc != nil {
@@ -132,14 +200,30 @@ func Middleware(tr opentracing.Tracer, options ...MWOption) gin.HandlerFunc {
 		c.Request = c.Request.WithContext(
 			opentracing.ContextWithSpan(c.Request.Context(), sp))
 
+		setHeaderTags(sp, "http.request.header.", c.Request.Header, opts.requestHeaders)
+
 		defer recovery(sp)
 
 		c.Next()
 
-		if opts.errorFunc(c) {
+		if opts.useRouteTemplate {
+			if route := c.FullPath(); route != "" {
+				sp.SetOperationName(route)
+				sp.SetTag("http.route", route)
+			} else {
+				sp.SetOperationName(unmatchedOperationName(c.Request))
+			}
+		}
+
+		if opts.errorFunc(c, rw) {
 			ext.Error.Set(sp, true)
 		}
-		ext.HTTPStatusCode.Set(sp, uint16(c.Writer.Status()))
+		ext.HTTPStatusCode.Set(sp, uint16(rw.Status()))
+		if opts.responseSizeTag {
+			sp.SetTag("http.response_content_length", rw.Size())
+		}
+		setHeaderTags(sp, "http.response.header.", rw.Header(), opts.responseHeaders)
+		logErrors(sp, c)
 		sp.Finish()
 	}
 }
@@ -159,6 +243,38 @@ func setIp(addr string, sp opentracing.Span) {
 	}
 }
 
+// setHeaderTags adds a prefix+<lower_snake_case name> span tag for each
+// header in names found in h. A single value is tagged as a string, multiple
+// values as a string slice.
+func setHeaderTags(sp opentracing.Span, prefix string, h http.Header, names []string) {
+	for _, name := range names {
+		values := h[http.CanonicalHeaderKey(name)]
+		if len(values) == 0 {
+			continue
+		}
+		tag := prefix + strcase.SnakeCase(name)
+		if len(values) == 1 {
+			sp.SetTag(tag, values[0])
+		} else {
+			sp.SetTag(tag, values)
+		}
+	}
+}
+
+// logErrors records a gin.Context's accumulated errors as an OpenTracing
+// span log, so tracers like Jaeger/Zipkin render them as error annotations
+// rather than just the http.status_code/error tags.
+func logErrors(sp opentracing.Span, c *gin.Context) {
+	if len(c.Errors) == 0 {
+		return
+	}
+	sp.LogFields(
+		otlog.String("event", "error"),
+		otlog.String("message", c.Errors.String()),
+		otlog.String("error.kind", "gin"),
+	)
+}
+
 func urlTag(c *gin.Context) string {
 	var proto string
 	if c.Request.TLS == nil {
@@ -169,16 +285,11 @@ func urlTag(c *gin.Context) string {
 	return fmt.Sprintf("%s://%s%s", proto, c.Request.Host, c.Request.URL.Path)
 }
 
-// DefaultOperationName is the default when tracer gets passed nil. It converts the
-// URL path to CamelCase without a leading "api", e.g. "/api/v1//entities/" -> "V1Entities"
-// or "/rest/kairosdbs/kairosdb/api/v1/datapoints/query" ->
-// "RestKairosdbsKairosdbApiV1DatapointsQuery"
-func defaultOperationName(r *http.Request) string {
-	url := strings.Split(strings.Replace(r.URL.Path, "//", "/", -1)[1:], "/") // exclude leading "/"
-	if url[0] == "api" {
-		url = url[1:]
-	}
-	return strcase.UpperCamelCase(strings.Join(url, "_"))
+// unmatchedOperationName is used as the span's operation name when the
+// route template isn't available, either because routing hasn't happened
+// yet or because the request didn't match any route (e.g. a 404).
+func unmatchedOperationName(r *http.Request) string {
+	return "HTTP " + r.Method
 }
 
 func recovery(sp opentracing.Span) {
@@ -1,6 +1,7 @@
 package ginhttp_test
 
 import (
+	"errors"
 	"fmt"
 	"github.com/opentracing/opentracing-go/ext"
 	"net"
@@ -22,8 +23,10 @@ type testCase struct {
 	name                  string
 	handler               gin.HandlerFunc
 	options               []ginhttp.MWOption
+	requestHeaders        map[string]string
 	expectedOperationName string
 	expectedSpanTags      []map[string]interface{}
+	noRouteTag            bool
 }
 
 func TestTags(t *testing.T) {
@@ -88,6 +91,7 @@ func TestTags(t *testing.T) {
 				return "HTTP " + r.Method + ": /root"
 			})},
 			expectedOperationName: "HTTP GET: /root",
+			noRouteTag:            true,
 			expectedSpanTags: []map[string]interface{}{
 				{
 					string(ext.Component):      defaultComponentName,
@@ -117,8 +121,8 @@ func TestTags(t *testing.T) {
 			handler: func(c *gin.Context) {
 				c.String(http.StatusNotFound, "OK")
 			},
-			options: []ginhttp.MWOption{ginhttp.MWErrorFunc(func(ctx *gin.Context) bool {
-				return ctx.Writer.Status() >= http.StatusNotFound
+			options: []ginhttp.MWOption{ginhttp.MWErrorFunc(func(ctx *gin.Context, w *ginhttp.ResponseWriter) bool {
+				return w.Status() >= http.StatusNotFound
 			})},
 			expectedSpanTags: []map[string]interface{}{
 				{
@@ -130,11 +134,51 @@ func TestTags(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Capture request and response headers",
+			handler: func(c *gin.Context) {
+				c.Writer.Header().Set("X-Request-Id", "abc123")
+				c.String(http.StatusOK, "OK")
+			},
+			options: []ginhttp.MWOption{
+				ginhttp.MWCaptureRequestHeaders([]string{"X-Test-Header"}),
+				ginhttp.MWCaptureResponseHeaders([]string{"X-Request-Id"}),
+			},
+			requestHeaders: map[string]string{"X-Test-Header": "hello"},
+			expectedSpanTags: []map[string]interface{}{
+				{
+					string(ext.Component):               defaultComponentName,
+					string(ext.HTTPMethod):               "GET",
+					string(ext.HTTPStatusCode):            uint16(http.StatusOK),
+					string(ext.SpanKind):                  ext.SpanKindRPCServerEnum,
+					"http.request.header.x_test_header":   "hello",
+					"http.response.header.x_request_id":   "abc123",
+				},
+			},
+		},
+		{
+			name: "ResponseSizeTag option",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			},
+			options: []ginhttp.MWOption{ginhttp.MWResponseSizeTag()},
+			expectedSpanTags: []map[string]interface{}{
+				{
+					string(ext.Component):        defaultComponentName,
+					string(ext.HTTPMethod):        "GET",
+					string(ext.HTTPStatusCode):    uint16(http.StatusOK),
+					string(ext.SpanKind):           ext.SpanKindRPCServerEnum,
+					"http.response_content_length": 2,
+				},
+			},
+		},
 		{
 			name: "Panic",
 			handler: func(c *gin.Context) {
 				panic("panic test")
 			},
+			expectedOperationName: "HTTP GET",
+			noRouteTag:            true,
 			expectedSpanTags: []map[string]interface{}{
 				{
 					string(ext.Component):      defaultComponentName,
@@ -162,6 +206,9 @@ func TestTags(t *testing.T) {
 
 			request, err := http.NewRequest("GET", srv.URL+"/hello?token=secret", nil)
 			assert.NoError(t, err)
+			for k, v := range tt.requestHeaders {
+				request.Header.Set(k, v)
+			}
 			_, err = http.DefaultClient.Do(request)
 			assert.NoError(t, err)
 
@@ -176,9 +223,51 @@ func TestTags(t *testing.T) {
 	}
 }
 
+func TestUnmatchedRouteOperationName(t *testing.T) {
+	tracer := &mocktracer.MockTracer{}
+	srv := httptest.NewServer(engine(tracer, func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	}, nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/does-not-exist")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "HTTP GET", spans[0].OperationName)
+	assert.NotContains(t, spans[0].Tags(), "http.route")
+}
+
+func TestGinErrorsLoggedOnSpan(t *testing.T) {
+	tracer := &mocktracer.MockTracer{}
+	srv := httptest.NewServer(engine(tracer, func(c *gin.Context) {
+		_ = c.Error(errors.New("boom"))
+		c.String(http.StatusInternalServerError, "oops")
+	}, nil))
+	defer srv.Close()
+
+	_, err := http.Get(srv.URL + "/hello")
+	assert.NoError(t, err)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	logs := spans[0].Logs()
+	assert.Len(t, logs, 1)
+
+	fields := map[string]interface{}{}
+	for _, f := range logs[0].Fields {
+		fields[f.Key] = f.ValueString
+	}
+	assert.Equal(t, "error", fields["event"])
+	assert.Equal(t, "gin", fields["error.kind"])
+	assert.Contains(t, fields["message"], "boom")
+}
+
 func setDefaults(tt *testCase, listener net.Listener) {
 	if tt.expectedOperationName == "" {
-		tt.expectedOperationName = "Hello"
+		tt.expectedOperationName = "/hello"
 	}
 
 	for _, tags := range tt.expectedSpanTags {
@@ -186,6 +275,9 @@ func setDefaults(tt *testCase, listener net.Listener) {
 		if _, ok := tags[string(ext.HTTPUrl)]; !ok {
 			tags[string(ext.HTTPUrl)] = fmt.Sprintf("http://%s/hello", listener.Addr())
 		}
+		if !tt.noRouteTag {
+			tags["http.route"] = "/hello"
+		}
 	}
 }
 
@@ -0,0 +1,196 @@
+// +build go1.7
+
+// This is the OpenTelemetry counterpart of github.com/zeitlinger/go-gin/ginhttp.
+//
+// It offers the core options of the OpenTracing-based middleware
+// (OperationNameFunc, MWComponentName, MWSpanObserver, MWURLTagFunc and
+// MWErrorFunc, the last taking only the gin.Context). ginhttp's route
+// template naming, header capture, response size tag and response body
+// capture options have no counterpart here.
+
+package ginotel
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultComponentName = "net/http"
+
+type mwOptions struct {
+	opNameFunc    func(r *http.Request) string
+	spanObserver  func(span trace.Span, r *http.Request)
+	urlTagFunc    func(u *url.URL) string
+	errorFunc     func(ctx *gin.Context) bool
+	componentName string
+}
+
+// MWOption controls the behavior of the Middleware.
+type MWOption func(*mwOptions)
+
+// OperationNameFunc returns a MWOption that uses given function f
+// to generate operation name for each server-side span.
+func OperationNameFunc(f func(r *http.Request) string) MWOption {
+	if f == nil {
+		panic("nil OperationNameFunc")
+	}
+
+	return func(options *mwOptions) {
+		options.opNameFunc = f
+	}
+}
+
+// MWComponentName returns a MWOption that sets the component name
+// for the server-side span.
+func MWComponentName(componentName string) MWOption {
+	if componentName == "" {
+		panic("empty componentName")
+	}
+
+	return func(options *mwOptions) {
+		options.componentName = componentName
+	}
+}
+
+// MWSpanObserver returns a MWOption that observe the span
+// for the server-side span.
+func MWSpanObserver(f func(span trace.Span, r *http.Request)) MWOption {
+	if f == nil {
+		panic("nil MWSpanObserver")
+	}
+
+	return func(options *mwOptions) {
+		options.spanObserver = f
+	}
+}
+
+// MWURLTagFunc returns a MWOption that uses given function f
+// to set the span's http.url tag. Can be used to change the default
+// http.url tag, eg to redact sensitive information.
+func MWURLTagFunc(f func(u *url.URL) string) MWOption {
+	if f == nil {
+		panic("nil MWURLTagFunc")
+	}
+
+	return func(options *mwOptions) {
+		options.urlTagFunc = f
+	}
+}
+
+// MWErrorFunc returns a MWOption that sets the span error status
+func MWErrorFunc(f func(ctx *gin.Context) bool) MWOption {
+	if f == nil {
+		panic("nil MWErrorFunc")
+	}
+
+	return func(options *mwOptions) {
+		options.errorFunc = f
+	}
+}
+
+// Middleware is the OpenTelemetry equivalent of ginhttp.Middleware. It
+// extracts any upstream trace context, starts a server-kind span for the
+// request, and injects the span into the request context so handlers can
+// retrieve it with trace.SpanFromContext.
+func Middleware(tp trace.TracerProvider, options ...MWOption) gin.HandlerFunc {
+	opts := mwOptions{
+		opNameFunc:   defaultOperationName,
+		spanObserver: func(span trace.Span, r *http.Request) {},
+		errorFunc: func(ctx *gin.Context) bool {
+			return ctx.Writer.Status() >= http.StatusInternalServerError
+		},
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	tracer := tp.Tracer(defaultComponentName)
+
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		op := opts.opNameFunc(c.Request)
+		ctx, sp := tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindServer))
+
+		sp.SetAttributes(attribute.String("http.method", c.Request.Method))
+		if opts.urlTagFunc != nil {
+			sp.SetAttributes(attribute.String("http.url", opts.urlTagFunc(c.Request.URL)))
+		} else {
+			sp.SetAttributes(attribute.String("http.url", urlTag(c)))
+		}
+		setPeer(c.Request.RemoteAddr, sp)
+
+		opts.spanObserver(sp, c.Request)
+
+		// set component name, use "net/http" if caller does not specify
+		componentName := opts.componentName
+		if componentName == "" {
+			componentName = defaultComponentName
+		}
+		sp.SetAttributes(attribute.String("component", componentName))
+		c.Request = c.Request.WithContext(ctx)
+
+		defer recovery(sp)
+
+		c.Next()
+
+		if route := c.FullPath(); route != "" {
+			sp.SetAttributes(attribute.String("http.route", route))
+		}
+
+		if opts.errorFunc(c) {
+			sp.SetStatus(codes.Error, "")
+		}
+		sp.SetAttributes(attribute.Int("http.status_code", c.Writer.Status()))
+		sp.End()
+	}
+}
+
+func setPeer(addr string, sp trace.Span) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		sp.SetAttributes(attribute.String("net.peer.ip", ip.String()))
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		sp.SetAttributes(attribute.Int("net.peer.port", p))
+	}
+}
+
+func urlTag(c *gin.Context) string {
+	var proto string
+	if c.Request.TLS == nil {
+		proto = "http"
+	} else {
+		proto = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", proto, c.Request.Host, c.Request.URL.Path)
+}
+
+// defaultOperationName builds a span name of the form "GET /users/1" from
+// the raw request path.
+func defaultOperationName(r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
+
+func recovery(sp trace.Span) {
+	if err := recover(); err != nil {
+		sp.SetAttributes(attribute.Int("http.status_code", http.StatusInternalServerError))
+		sp.RecordError(fmt.Errorf("%v", err))
+		sp.SetStatus(codes.Error, fmt.Sprintf("panic: %v", err))
+		sp.End()
+		panic(err)
+	}
+}
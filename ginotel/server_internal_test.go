@@ -0,0 +1,52 @@
+package ginotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetPeer(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		expected []attribute.KeyValue
+	}{
+		{
+			name: "IPv4",
+			addr: "192.168.0.1:124",
+			expected: []attribute.KeyValue{
+				attribute.String("net.peer.ip", "192.168.0.1"),
+				attribute.Int("net.peer.port", 124),
+			},
+		},
+		{
+			name: "IPv6",
+			addr: "[::1]:8080",
+			expected: []attribute.KeyValue{
+				attribute.String("net.peer.ip", "::1"),
+				attribute.Int("net.peer.port", 8080),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			_, sp := tp.Tracer("test").Start(context.Background(), "op")
+
+			setPeer(tt.addr, sp)
+			sp.End()
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			for _, kv := range tt.expected {
+				assert.Contains(t, spans[0].Attributes, kv)
+			}
+		})
+	}
+}
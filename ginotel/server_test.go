@@ -0,0 +1,84 @@
+package ginotel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeitlinger/go-gin/ginotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        gin.HandlerFunc
+		options        []ginotel.MWOption
+		expectedCode   codes.Code
+		expectedStatus int
+	}{
+		{
+			name:           "OK",
+			expectedCode:   codes.Unset,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Error",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusInternalServerError, "OK")
+			},
+			expectedCode:   codes.Error,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Error func option",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusNotFound, "OK")
+			},
+			options: []ginotel.MWOption{ginotel.MWErrorFunc(func(ctx *gin.Context) bool {
+				return ctx.Writer.Status() >= http.StatusNotFound
+			})},
+			expectedCode:   codes.Error,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			handler := tt.handler
+			if handler == nil {
+				handler = func(c *gin.Context) {
+					c.String(http.StatusOK, "OK")
+				}
+			}
+
+			r := gin.New()
+			r.Use(gin.Recovery(), ginotel.Middleware(tp, tt.options...))
+			r.GET("/hello", handler)
+
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			request, err := http.NewRequest("GET", srv.URL+"/hello", nil)
+			assert.NoError(t, err)
+			_, err = http.DefaultClient.Do(request)
+			assert.NoError(t, err)
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tt.expectedCode, span.Status.Code)
+			assert.Contains(t, span.Attributes, attribute.String("http.method", "GET"))
+			assert.Contains(t, span.Attributes, attribute.Int("http.status_code", tt.expectedStatus))
+			assert.Contains(t, span.Attributes, attribute.String("http.route", "/hello"))
+		})
+	}
+}